@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/coreos/coreos-kubernetes/multi-node/aws/pkg/cluster"
+	"github.com/coreos/coreos-kubernetes/multi-node/aws/pkg/config"
+)
+
+// commonFlags registers the flags shared by every kube-aws subcommand.
+func commonFlags(fs *flag.FlagSet) (configPath, stackPath *string, awsDebug *bool) {
+	configPath = fs.String("config", "cluster.json", "path to the cluster config")
+	stackPath = fs.String("stack-template", "stack-template.json", "path to the rendered CloudFormation stack template")
+	awsDebug = fs.Bool("aws-debug", false, "log AWS API requests and responses")
+	return
+}
+
+// loadCluster reads the cluster config and rendered stack template from disk.
+func loadCluster(configPath, stackPath string, awsDebug bool) (*cluster.Cluster, string, error) {
+	configBytes, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading cluster config %s: %v", configPath, err)
+	}
+
+	var cfg config.Cluster
+	if err := json.Unmarshal(configBytes, &cfg); err != nil {
+		return nil, "", fmt.Errorf("error parsing cluster config %s: %v", configPath, err)
+	}
+
+	stackBytes, err := ioutil.ReadFile(stackPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading stack template %s: %v", stackPath, err)
+	}
+
+	return cluster.New(&cfg, awsDebug), string(stackBytes), nil
+}
@@ -0,0 +1,30 @@
+// Command kube-aws drives the pkg/cluster library against a rendered
+// CloudFormation stack template.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: kube-aws <command> [options]")
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "up":
+		err = runUp(os.Args[2:])
+	case "update":
+		err = runUpdate(os.Args[2:])
+	default:
+		err = fmt.Errorf("unknown command %q", os.Args[1])
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
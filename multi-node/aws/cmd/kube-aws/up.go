@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"os"
+)
+
+// runUp implements `kube-aws up`. With --resume it attaches to a stack
+// already in progress instead of creating a new one.
+func runUp(args []string) error {
+	fs := flag.NewFlagSet("up", flag.ExitOnError)
+	configPath, stackPath, awsDebug := commonFlags(fs)
+	resume := fs.String("resume", "", "attach to an existing stack by name instead of creating a new one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, stackBody, err := loadCluster(*configPath, *stackPath, *awsDebug)
+	if err != nil {
+		return err
+	}
+
+	if *resume != "" {
+		return c.Adopt(*resume, os.Stdout)
+	}
+	return c.Create(stackBody, os.Stdout)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/coreos/coreos-kubernetes/multi-node/aws/pkg/cluster"
+)
+
+// runUpdate implements `kube-aws update`. With --plan it prints a change
+// set summary instead of updating; with --apply-changeset it executes a
+// change set a prior --plan run printed.
+func runUpdate(args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	configPath, stackPath, awsDebug := commonFlags(fs)
+	plan := fs.Bool("plan", false, "compute and print a change set instead of updating")
+	applyChangeSet := fs.String("apply-changeset", "", "execute a change set previously printed by --plan")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, stackBody, err := loadCluster(*configPath, *stackPath, *awsDebug)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *applyChangeSet != "":
+		return c.ApplyChangeSet(*applyChangeSet, os.Stdout)
+	case *plan:
+		summary, err := c.Plan(stackBody)
+		if err != nil {
+			return err
+		}
+		printChangeSetSummary(summary)
+		return nil
+	default:
+		_, err := c.Update(stackBody, os.Stdout)
+		return err
+	}
+}
+
+func printChangeSetSummary(s *cluster.ChangeSetSummary) {
+	fmt.Printf("change set: %s\n", s.Name)
+	if len(s.Changes) == 0 {
+		fmt.Println("no changes")
+		return
+	}
+	for _, change := range s.Changes {
+		fmt.Printf("%s\t%s\t%s\treplacement=%t\t%v\n",
+			change.Action, change.LogicalResourceID, change.ResourceType, change.Replacement, change.ChangedProperties)
+	}
+	fmt.Printf("\napply with: kube-aws update --apply-changeset=%s\n", s.Name)
+}
@@ -0,0 +1,138 @@
+package cluster
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+// ChangeSetSummary is a digest of a CloudFormation change set returned by
+// Cluster.Plan for review before Cluster.ApplyChangeSet.
+type ChangeSetSummary struct {
+	Name    string
+	Changes []ResourceChange
+}
+
+type ResourceChange struct {
+	LogicalResourceID string
+	ResourceType      string
+	Action            string
+	Replacement       bool
+	ChangedProperties []string
+}
+
+var noChangesExpr = regexp.MustCompile(`(?i)no changes|didn't contain changes`)
+
+func (c *Cluster) changeSetName() string {
+	return fmt.Sprintf("%s-%d", c.ClusterName, time.Now().UnixNano())
+}
+
+// Plan computes a change set for stackBody against the cluster's existing
+// stack and returns a summary of what it would change.
+func (c *Cluster) Plan(stackBody string) (*ChangeSetSummary, error) {
+	cfSvc := cloudformation.New(c.session)
+	name := c.changeSetName()
+
+	params, err := c.resolveStackParameters()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = cfSvc.CreateChangeSet(&cloudformation.CreateChangeSetInput{
+		StackName:     aws.String(c.ClusterName),
+		ChangeSetName: aws.String(name),
+		ChangeSetType: aws.String(cloudformation.ChangeSetTypeUpdate),
+		Capabilities:  []*string{aws.String(cloudformation.CapabilityCapabilityIam)},
+		TemplateBody:  aws.String(stackBody),
+		Parameters:    params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating change set: %v", err)
+	}
+
+	describeInput := &cloudformation.DescribeChangeSetInput{
+		StackName:     aws.String(c.ClusterName),
+		ChangeSetName: aws.String(name),
+	}
+	for {
+		resp, err := cfSvc.DescribeChangeSet(describeInput)
+		if err != nil {
+			return nil, fmt.Errorf("error describing change set: %v", err)
+		}
+
+		switch aws.StringValue(resp.Status) {
+		case cloudformation.ChangeSetStatusCreateComplete:
+			return newChangeSetSummary(name, resp.Changes), nil
+		case cloudformation.ChangeSetStatusFailed:
+			reason := aws.StringValue(resp.StatusReason)
+			if noChangesExpr.MatchString(reason) {
+				return &ChangeSetSummary{Name: name}, nil
+			}
+			return nil, fmt.Errorf("change set failed: %s", reason)
+		case cloudformation.ChangeSetStatusCreateInProgress, cloudformation.ChangeSetStatusCreatePending:
+			time.Sleep(3 * time.Second)
+			continue
+		default:
+			return nil, fmt.Errorf("unexpected change set status: %s", aws.StringValue(resp.Status))
+		}
+	}
+}
+
+func newChangeSetSummary(name string, changes []*cloudformation.Change) *ChangeSetSummary {
+	summary := &ChangeSetSummary{Name: name}
+	for _, change := range changes {
+		rc := change.ResourceChange
+		if rc == nil {
+			continue
+		}
+
+		var props []string
+		for _, d := range rc.Details {
+			if d.Target != nil && d.Target.Attribute != nil {
+				props = append(props, aws.StringValue(d.Target.Attribute))
+			}
+		}
+
+		summary.Changes = append(summary.Changes, ResourceChange{
+			LogicalResourceID: aws.StringValue(rc.LogicalResourceId),
+			ResourceType:      aws.StringValue(rc.ResourceType),
+			Action:            aws.StringValue(rc.Action),
+			Replacement:       aws.StringValue(rc.Replacement) == cloudformation.ReplacementTrue,
+			ChangedProperties: props,
+		})
+	}
+	return summary
+}
+
+// ApplyChangeSet executes a change set returned by Plan and streams the
+// resulting stack update's events to out.
+func (c *Cluster) ApplyChangeSet(name string, out io.Writer) error {
+	cfSvc := cloudformation.New(c.session)
+	if _, err := cfSvc.ExecuteChangeSet(&cloudformation.ExecuteChangeSetInput{
+		StackName:     aws.String(c.ClusterName),
+		ChangeSetName: aws.String(name),
+	}); err != nil {
+		return fmt.Errorf("error executing change set: %v", err)
+	}
+
+	if _, err := streamStackEvents(cfSvc, c.ClusterName, out); err != nil {
+		return fmt.Errorf("stack update failed: %v", err)
+	}
+	return nil
+}
+
+// DiscardChangeSet deletes a change set returned by Plan without applying it.
+func (c *Cluster) DiscardChangeSet(name string) error {
+	cfSvc := cloudformation.New(c.session)
+	if _, err := cfSvc.DeleteChangeSet(&cloudformation.DeleteChangeSetInput{
+		StackName:     aws.String(c.ClusterName),
+		ChangeSetName: aws.String(name),
+	}); err != nil {
+		return fmt.Errorf("error deleting change set: %v", err)
+	}
+	return nil
+}
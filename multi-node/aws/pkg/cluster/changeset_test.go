@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestNewChangeSetSummary(t *testing.T) {
+	changes := []*cloudformation.Change{
+		{
+			ResourceChange: &cloudformation.ResourceChange{
+				LogicalResourceId: aws.String("EIPController"),
+				ResourceType:      aws.String("AWS::EC2::EIP"),
+				Action:            aws.String(cloudformation.ChangeActionRemove),
+				Replacement:       aws.String(cloudformation.ReplacementTrue),
+				Details: []*cloudformation.ResourceChangeDetail{
+					{Target: &cloudformation.ResourceTargetDefinition{Attribute: aws.String("Properties")}},
+				},
+			},
+		},
+		{
+			// Not a resource change (e.g. a condition-only change); should be skipped.
+			ResourceChange: nil,
+		},
+	}
+
+	summary := newChangeSetSummary("my-change-set", changes)
+
+	if summary.Name != "my-change-set" {
+		t.Errorf("expected name my-change-set, got %s", summary.Name)
+	}
+	if len(summary.Changes) != 1 {
+		t.Fatalf("expected 1 resource change, got %d", len(summary.Changes))
+	}
+
+	rc := summary.Changes[0]
+	if rc.LogicalResourceID != "EIPController" {
+		t.Errorf("expected LogicalResourceID EIPController, got %s", rc.LogicalResourceID)
+	}
+	if !rc.Replacement {
+		t.Error("expected Replacement to be true")
+	}
+	if len(rc.ChangedProperties) != 1 || rc.ChangedProperties[0] != "Properties" {
+		t.Errorf("expected ChangedProperties [Properties], got %v", rc.ChangedProperties)
+	}
+}
+
+func TestNoChangesExpr(t *testing.T) {
+	if !noChangesExpr.MatchString("The submitted information didn't contain changes. Submit different information to create a change set.") {
+		t.Error("expected the standard CloudFormation 'no changes' reason to match")
+	}
+	if noChangesExpr.MatchString("some other failure") {
+		t.Error("did not expect an unrelated failure reason to match")
+	}
+}
@@ -2,17 +2,17 @@ package cluster
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
+	"io"
 	"net"
 	"regexp"
 	"text/tabwriter"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 
 	"github.com/coreos/coreos-kubernetes/multi-node/aws/pkg/config"
 )
@@ -72,11 +72,10 @@ func (c *Cluster) ValidateStack(stackBody string) (string, error) {
 
 	//Find out if stack exists already. This determines whether we should do subnet conflict validatio
 	var stackExists bool
-	stackNotExistExpr := regexp.MustCompile(fmt.Sprintf("^ValidationError: Stack with id %s does not exist", c.ClusterName))
 
 	describeStacksOutput, err := cfSvc.DescribeStacks(&describeStacksInput)
 	if err != nil {
-		if stackNotExistExpr.Match([]byte(err.Error())) {
+		if stackNotExistErr(err, c.ClusterName) {
 			//No results for a list operation is not an error!!! (unless your AWS)
 			stackExists = false
 		} else {
@@ -100,6 +99,16 @@ func (c *Cluster) ValidateStack(stackBody string) (string, error) {
 	return validationReport.String(), nil
 }
 
+// stackNotExistErr reports whether err is the ValidationError CloudFormation
+// returns when describing a stack name that doesn't exist.
+func stackNotExistErr(err error, stackName string) bool {
+	if err == nil {
+		return false
+	}
+	expr := regexp.MustCompile(fmt.Sprintf("^ValidationError: Stack with id %s does not exist", regexp.QuoteMeta(stackName)))
+	return expr.MatchString(err.Error())
+}
+
 func (c *Cluster) validateExistingVPC() error {
 	ec2Svc := ec2.New(c.session)
 
@@ -139,6 +148,10 @@ func (c *Cluster) validateExistingVPC() error {
 		return fmt.Errorf("error describing subnets for vpc: %v", err)
 	}
 
+	if len(c.Subnets) > 0 {
+		return c.validateSubnets(ec2Svc, existingVPC, subnetOutput.Subnets)
+	}
+
 	//Config validation has already ensured this subnet is contained by the existing VPC CIDR
 	//We need access to the net objects so we can detect conflicts
 	subnetIP, subnetNet, err := net.ParseCIDR(c.InstanceCIDR)
@@ -161,84 +174,55 @@ func (c *Cluster) validateExistingVPC() error {
 	return nil
 }
 
-func (c *Cluster) Create(stackBody string) error {
-	cfSvc := cloudformation.New(c.session)
-	creq := &cloudformation.CreateStackInput{
-		StackName:    aws.String(c.ClusterName),
-		OnFailure:    aws.String("DO_NOTHING"),
-		Capabilities: []*string{aws.String(cloudformation.CapabilityCapabilityIam)},
-		TemplateBody: &stackBody,
-	}
-
-	resp, err := cfSvc.CreateStack(creq)
+// validateSubnets validates each configured zone-aware subnet against the
+// existing VPC.
+func (c *Cluster) validateSubnets(ec2Svc ec2iface.EC2API, vpc *ec2.Vpc, existing []*ec2.Subnet) error {
+	_, vpcNet, err := net.ParseCIDR(*vpc.CidrBlock)
 	if err != nil {
-		return err
+		return fmt.Errorf("error parsing vpc cidr %s : %v", *vpc.CidrBlock, err)
 	}
 
-	req := cloudformation.DescribeStacksInput{
-		StackName: resp.StackId,
-	}
-	for {
-		resp, err := cfSvc.DescribeStacks(&req)
-		if err != nil {
+	for _, subnet := range c.Subnets {
+		if err := validateZone(ec2Svc, subnet); err != nil {
 			return err
 		}
-		if len(resp.Stacks) == 0 {
-			return fmt.Errorf("stack not found")
+		if err := validateSubnetCIDR(subnet.InstanceCIDR, vpcNet, existing); err != nil {
+			return err
 		}
-		statusString := aws.StringValue(resp.Stacks[0].StackStatus)
-		switch statusString {
-		case cloudformation.ResourceStatusCreateComplete:
-			return nil
-		case cloudformation.ResourceStatusCreateFailed:
-			errMsg := fmt.Sprintf("Stack creation failed: %s : %s", statusString, aws.StringValue(resp.Stacks[0].StackStatusReason))
-			return errors.New(errMsg)
-		case cloudformation.ResourceStatusCreateInProgress:
-			time.Sleep(3 * time.Second)
-			continue
-		default:
-			return fmt.Errorf("unexpected stack status: %s", statusString)
+		if err := validateWavelengthRouteTables(ec2Svc, subnet, *vpc.VpcId); err != nil {
+			return err
 		}
 	}
+
+	return nil
 }
 
-func (c *Cluster) Update(stackBody string) (string, error) {
+// Update starts a CloudFormation stack update and streams its events to out
+// until the stack reaches a terminal state.
+func (c *Cluster) Update(stackBody string, out io.Writer) (string, error) {
 	cfSvc := cloudformation.New(c.session)
+
+	params, err := c.resolveStackParameters()
+	if err != nil {
+		return "", err
+	}
+
 	input := &cloudformation.UpdateStackInput{
 		Capabilities: []*string{aws.String(cloudformation.CapabilityCapabilityIam)},
 		StackName:    aws.String(c.ClusterName),
 		TemplateBody: &stackBody,
+		Parameters:   params,
 	}
 
 	updateOutput, err := cfSvc.UpdateStack(input)
 	if err != nil {
 		return "", fmt.Errorf("error updating cloudformation stack: %v", err)
 	}
-	req := cloudformation.DescribeStacksInput{
-		StackName: updateOutput.StackId,
-	}
-	for {
-		resp, err := cfSvc.DescribeStacks(&req)
-		if err != nil {
-			return "", err
-		}
-		if len(resp.Stacks) == 0 {
-			return "", fmt.Errorf("stack not found")
-		}
-		statusString := aws.StringValue(resp.Stacks[0].StackStatus)
-		switch statusString {
-		case cloudformation.ResourceStatusUpdateComplete:
-			return updateOutput.String(), nil
-		case cloudformation.ResourceStatusUpdateFailed, cloudformation.StackStatusUpdateRollbackComplete, cloudformation.StackStatusUpdateRollbackFailed:
-			errMsg := fmt.Sprintf("Stack status: %s : %s", statusString, aws.StringValue(resp.Stacks[0].StackStatusReason))
-			return "", errors.New(errMsg)
-		case cloudformation.ResourceStatusUpdateInProgress:
-			time.Sleep(3 * time.Second)
-			continue
-		default:
-			return "", fmt.Errorf("unexpected stack status: %s", statusString)
-		}
+
+	if _, err := streamStackEvents(cfSvc, aws.StringValue(updateOutput.StackId), out); err != nil {
+		return "", fmt.Errorf("stack update failed: %v", err)
 	}
+	return updateOutput.String(), nil
 }
 
 func (c *Cluster) Info() (*ClusterInfo, error) {
@@ -276,11 +260,20 @@ func (c *Cluster) Info() (*ClusterInfo, error) {
 	return &info, nil
 }
 
-func (c *Cluster) Destroy() error {
+// Destroy deletes the cluster's CloudFormation stack and streams its events
+// to out until the stack is gone.
+func (c *Cluster) Destroy(out io.Writer) error {
 	cfSvc := cloudformation.New(c.session)
 	dreq := &cloudformation.DeleteStackInput{
 		StackName: aws.String(c.ClusterName),
 	}
-	_, err := cfSvc.DeleteStack(dreq)
-	return err
+	if _, err := cfSvc.DeleteStack(dreq); err != nil {
+		return err
+	}
+
+	if _, err := streamStackEvents(cfSvc, c.ClusterName, out); err != nil {
+		return fmt.Errorf("stack deletion failed: %v", err)
+	}
+
+	return c.teardownKeyPair()
 }
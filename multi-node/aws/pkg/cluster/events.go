@@ -0,0 +1,146 @@
+package cluster
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+)
+
+// eventSink prints new CloudFormation stack events and remembers the first
+// resource-level failure it sees.
+type eventSink struct {
+	out          io.Writer
+	seen         map[string]bool
+	firstFailure *cloudformation.StackEvent
+}
+
+func newEventSink(out io.Writer) *eventSink {
+	return &eventSink{
+		out:  out,
+		seen: make(map[string]bool),
+	}
+}
+
+func (s *eventSink) poll(cfSvc cloudformationiface.CloudFormationAPI, stackID string) error {
+	resp, err := cfSvc.DescribeStackEvents(&cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackID),
+	})
+	if err != nil {
+		return fmt.Errorf("error describing stack events: %v", err)
+	}
+
+	var fresh []*cloudformation.StackEvent
+	for _, e := range resp.StackEvents {
+		id := aws.StringValue(e.EventId)
+		if s.seen[id] {
+			continue
+		}
+		s.seen[id] = true
+		fresh = append(fresh, e)
+	}
+
+	// DescribeStackEvents returns events most-recent-first; print them in
+	// the order they actually happened.
+	for i := len(fresh) - 1; i >= 0; i-- {
+		e := fresh[i]
+		s.print(e)
+		if s.firstFailure == nil && isFailedResourceStatus(aws.StringValue(e.ResourceStatus)) {
+			s.firstFailure = e
+		}
+	}
+
+	return nil
+}
+
+func (s *eventSink) print(e *cloudformation.StackEvent) {
+	w := new(tabwriter.Writer)
+	w.Init(s.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+		e.Timestamp.Format(time.RFC3339),
+		aws.StringValue(e.LogicalResourceId),
+		aws.StringValue(e.ResourceStatus),
+		aws.StringValue(e.ResourceStatusReason),
+	)
+	w.Flush()
+}
+
+func isFailedResourceStatus(status string) bool {
+	switch status {
+	case cloudformation.ResourceStatusCreateFailed,
+		cloudformation.ResourceStatusUpdateFailed,
+		cloudformation.ResourceStatusDeleteFailed:
+		return true
+	}
+	return false
+}
+
+// terminalStackState reports whether status is a terminal state and, if so,
+// whether it's a failure.
+func terminalStackState(status string) (done bool, failed bool) {
+	switch status {
+	case cloudformation.ResourceStatusCreateComplete,
+		cloudformation.ResourceStatusUpdateComplete,
+		cloudformation.StackStatusDeleteComplete:
+		return true, false
+	case cloudformation.ResourceStatusCreateFailed,
+		cloudformation.ResourceStatusUpdateFailed,
+		cloudformation.StackStatusUpdateRollbackComplete,
+		cloudformation.StackStatusUpdateRollbackFailed,
+		cloudformation.StackStatusRollbackComplete,
+		cloudformation.StackStatusRollbackFailed,
+		cloudformation.StackStatusDeleteFailed:
+		return true, true
+	}
+	return false, false
+}
+
+// streamStackEvents tails stackID's events to out until it reaches a
+// terminal status, returning a StackFailedError if that status is a failure.
+func streamStackEvents(cfSvc cloudformationiface.CloudFormationAPI, stackID string, out io.Writer) (string, error) {
+	sink := newEventSink(out)
+
+	for {
+		resp, err := cfSvc.DescribeStacks(&cloudformation.DescribeStacksInput{
+			StackName: aws.String(stackID),
+		})
+		if err != nil {
+			if stackNotExistErr(err, stackID) {
+				return cloudformation.StackStatusDeleteComplete, nil
+			}
+			return "", err
+		}
+		if len(resp.Stacks) == 0 {
+			return cloudformation.StackStatusDeleteComplete, nil
+		}
+		stack := resp.Stacks[0]
+
+		if err := sink.poll(cfSvc, stackID); err != nil {
+			return "", err
+		}
+
+		status := aws.StringValue(stack.StackStatus)
+		if done, failed := terminalStackState(status); done {
+			if !failed {
+				return status, nil
+			}
+			if sink.firstFailure != nil {
+				return status, &StackFailedError{
+					Status:            status,
+					LogicalResourceID: aws.StringValue(sink.firstFailure.LogicalResourceId),
+					Reason:            aws.StringValue(sink.firstFailure.ResourceStatusReason),
+				}
+			}
+			return status, &StackFailedError{
+				Status: status,
+				Reason: aws.StringValue(stack.StackStatusReason),
+			}
+		}
+
+		time.Sleep(3 * time.Second)
+	}
+}
@@ -0,0 +1,149 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+)
+
+// fakeCFClient is a minimal cloudformationiface.CloudFormationAPI that drives
+// streamStackEvents through a scripted sequence of DescribeStacks/
+// DescribeStackEvents responses, one step per call.
+type fakeCFClient struct {
+	cloudformationiface.CloudFormationAPI
+
+	stacks []*cloudformation.DescribeStacksOutput
+	events []*cloudformation.DescribeStackEventsOutput
+
+	stacksCalls int
+	eventsCalls int
+}
+
+func (f *fakeCFClient) DescribeStacks(in *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	out := f.stacks[f.stacksCalls]
+	if f.stacksCalls < len(f.stacks)-1 {
+		f.stacksCalls++
+	}
+	return out, nil
+}
+
+func (f *fakeCFClient) DescribeStackEvents(in *cloudformation.DescribeStackEventsInput) (*cloudformation.DescribeStackEventsOutput, error) {
+	out := f.events[f.eventsCalls]
+	if f.eventsCalls < len(f.events)-1 {
+		f.eventsCalls++
+	}
+	return out, nil
+}
+
+func stackEvent(id, logicalID, status, reason string) *cloudformation.StackEvent {
+	ts := time.Unix(0, 0).UTC()
+	return &cloudformation.StackEvent{
+		EventId:              aws.String(id),
+		LogicalResourceId:    aws.String(logicalID),
+		ResourceStatus:       aws.String(status),
+		ResourceStatusReason: aws.String(reason),
+		Timestamp:            &ts,
+	}
+}
+
+// awsValidationErr mimics the error the AWS SDK returns from DescribeStacks
+// when asked about a stack name that doesn't exist.
+func awsValidationErr(stackName string) error {
+	return fmt.Errorf("ValidationError: Stack with id %s does not exist", stackName)
+}
+
+func TestStreamStackEventsSuccess(t *testing.T) {
+	client := &fakeCFClient{
+		stacks: []*cloudformation.DescribeStacksOutput{
+			{Stacks: []*cloudformation.Stack{{StackStatus: aws.String(cloudformation.ResourceStatusCreateInProgress)}}},
+			{Stacks: []*cloudformation.Stack{{StackStatus: aws.String(cloudformation.ResourceStatusCreateComplete)}}},
+		},
+		events: []*cloudformation.DescribeStackEventsOutput{
+			{StackEvents: []*cloudformation.StackEvent{
+				stackEvent("2", "Worker", cloudformation.ResourceStatusCreateInProgress, ""),
+				stackEvent("1", "Controller", cloudformation.ResourceStatusCreateInProgress, ""),
+			}},
+			{StackEvents: []*cloudformation.StackEvent{
+				stackEvent("3", "Worker", cloudformation.ResourceStatusCreateComplete, ""),
+				stackEvent("2", "Worker", cloudformation.ResourceStatusCreateInProgress, ""),
+				stackEvent("1", "Controller", cloudformation.ResourceStatusCreateInProgress, ""),
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	status, err := streamStackEventsForTest(client, &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != cloudformation.ResourceStatusCreateComplete {
+		t.Errorf("expected terminal status %s, got %s", cloudformation.ResourceStatusCreateComplete, status)
+	}
+
+	output := buf.String()
+	if strings.Count(output, "Controller") != 1 {
+		t.Errorf("expected the Controller event to be printed exactly once, got:\n%s", output)
+	}
+	if strings.Count(output, "Worker") != 2 {
+		t.Errorf("expected both Worker events to be printed exactly once each, got:\n%s", output)
+	}
+}
+
+func TestStreamStackEventsFailureSurfacesRootCause(t *testing.T) {
+	client := &fakeCFClient{
+		stacks: []*cloudformation.DescribeStacksOutput{
+			{Stacks: []*cloudformation.Stack{{
+				StackStatus:       aws.String(cloudformation.ResourceStatusCreateFailed),
+				StackStatusReason: aws.String("The following resource(s) failed to create"),
+			}}},
+		},
+		events: []*cloudformation.DescribeStackEventsOutput{
+			{StackEvents: []*cloudformation.StackEvent{
+				stackEvent("2", "Controller", cloudformation.ResourceStatusCreateFailed, "quota exceeded"),
+				stackEvent("1", "Worker", cloudformation.ResourceStatusCreateInProgress, ""),
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	_, err := streamStackEventsForTest(client, &buf)
+	if err == nil {
+		t.Fatal("expected an error for a failed stack")
+	}
+	if !strings.Contains(err.Error(), "Controller") || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("expected error to name the first failed resource, got: %v", err)
+	}
+}
+
+func TestStreamStackEventsTreatsNotFoundAsDeleted(t *testing.T) {
+	client := &notFoundCFClient{stackName: "my-cluster"}
+
+	status, err := streamStackEvents(client, "my-cluster", &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != cloudformation.StackStatusDeleteComplete {
+		t.Errorf("expected %s, got %s", cloudformation.StackStatusDeleteComplete, status)
+	}
+}
+
+type notFoundCFClient struct {
+	cloudformationiface.CloudFormationAPI
+	stackName string
+}
+
+func (f *notFoundCFClient) DescribeStacks(in *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	return nil, awsValidationErr(f.stackName)
+}
+
+// streamStackEventsForTest calls streamStackEvents with a fixed stack id,
+// matching the fakeCFClient fixtures above.
+func streamStackEventsForTest(client cloudformationiface.CloudFormationAPI, out *bytes.Buffer) (string, error) {
+	return streamStackEvents(client, "my-cluster", out)
+}
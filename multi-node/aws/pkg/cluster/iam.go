@@ -0,0 +1,44 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+// reconcileIAMRoles reconciles each of the cluster's externally-managed IAM
+// roles and returns CloudFormation parameters binding their ARNs.
+func (c *Cluster) reconcileIAMRoles() ([]*cloudformation.Parameter, error) {
+	var params []*cloudformation.Parameter
+	for _, role := range c.IAMRoles {
+		arn, err := role.Reconcile(c.session)
+		if err != nil {
+			return nil, fmt.Errorf("error reconciling iam role %s: %v", role.Name, err)
+		}
+		params = append(params, &cloudformation.Parameter{
+			ParameterKey:   aws.String(role.Name + "RoleArn"),
+			ParameterValue: aws.String(arn),
+		})
+	}
+	return params, nil
+}
+
+// resolveStackParameters gathers the CloudFormation parameters Create,
+// Update, and Plan all need to pass alongside the stack template.
+func (c *Cluster) resolveStackParameters() ([]*cloudformation.Parameter, error) {
+	params, err := c.reconcileIAMRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	keyParam, err := c.resolveKeyNameParameter()
+	if err != nil {
+		return nil, err
+	}
+	if keyParam != nil {
+		params = append(params, keyParam)
+	}
+
+	return params, nil
+}
@@ -0,0 +1,172 @@
+package cluster
+
+import (
+	"crypto/md5"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// keyPairManagedTag marks an EC2 key pair as one this tool imported, so
+// Destroy knows it's safe to remove.
+const keyPairManagedTag = "kube-aws:managed"
+
+// EnsureKeyPair makes sure AWS has a key pair matching localPublicKeyPath
+// and returns its name, importing it under the cluster's name if no key
+// with that fingerprint exists yet.
+func (c *Cluster) EnsureKeyPair(localPublicKeyPath string) (string, error) {
+	return ensureKeyPair(ec2.New(c.session), c.ClusterName, localPublicKeyPath)
+}
+
+func ensureKeyPair(ec2Svc ec2iface.EC2API, clusterName, localPublicKeyPath string) (string, error) {
+	pubKeyBytes, err := ioutil.ReadFile(localPublicKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading public key %s: %v", localPublicKeyPath, err)
+	}
+
+	fingerprint, err := rsaImportFingerprint(pubKeyBytes)
+	if err != nil {
+		return "", fmt.Errorf("error computing fingerprint of %s: %v", localPublicKeyPath, err)
+	}
+
+	resp, err := ec2Svc.DescribeKeyPairs(&ec2.DescribeKeyPairsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("fingerprint"), Values: []*string{aws.String(fingerprint)}},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error describing key pairs: %v", err)
+	}
+	if len(resp.KeyPairs) > 0 {
+		found := resp.KeyPairs[0]
+		// A prior import of this key may have succeeded while tagging it
+		// failed, leaving an untagged key pair teardownKeyPair won't
+		// recognize as managed. Heal that here rather than leaking it.
+		if aws.StringValue(found.KeyName) == clusterName && !keyPairIsManaged(found) {
+			if err := tagKeyPairManaged(ec2Svc, found.KeyPairId); err != nil {
+				return "", fmt.Errorf("error tagging previously imported key pair %s: %v", clusterName, err)
+			}
+		}
+		return aws.StringValue(found.KeyName), nil
+	}
+
+	imported, err := ec2Svc.ImportKeyPair(&ec2.ImportKeyPairInput{
+		KeyName:           aws.String(clusterName),
+		PublicKeyMaterial: pubKeyBytes,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error importing key pair %s: %v", clusterName, err)
+	}
+
+	if err := tagKeyPairManaged(ec2Svc, imported.KeyPairId); err != nil {
+		return "", fmt.Errorf("error tagging imported key pair %s: %v", clusterName, err)
+	}
+
+	return aws.StringValue(imported.KeyName), nil
+}
+
+func tagKeyPairManaged(ec2Svc ec2iface.EC2API, keyPairID *string) error {
+	_, err := ec2Svc.CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{keyPairID},
+		Tags:      []*ec2.Tag{{Key: aws.String(keyPairManagedTag), Value: aws.String("true")}},
+	})
+	return err
+}
+
+// resolveKeyNameParameter fills a blank c.KeyName from KeyPairPublicKeyPath
+// via EnsureKeyPair and returns it as a CloudFormation parameter, since
+// stackBody is already rendered by the time Create/Update/Plan run. A
+// KeyName that is already set is passed through without checking AWS, and
+// nil is returned when there is no key name at all.
+func (c *Cluster) resolveKeyNameParameter() (*cloudformation.Parameter, error) {
+	if c.KeyName == "" && c.KeyPairPublicKeyPath != "" {
+		keyName, err := c.EnsureKeyPair(c.KeyPairPublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("error ensuring key pair: %v", err)
+		}
+		c.KeyName = keyName
+	}
+	if c.KeyName == "" {
+		return nil, nil
+	}
+
+	return &cloudformation.Parameter{
+		ParameterKey:   aws.String("KeyName"),
+		ParameterValue: aws.String(c.KeyName),
+	}, nil
+}
+
+// rsaImportFingerprint computes the AWS import fingerprint of an RSA SSH
+// public key: the MD5 digest of its DER-encoded X.509 SubjectPublicKeyInfo
+// form (what `openssl rsa -pubout -outform DER` produces), not the bare
+// PKCS#1 {modulus, exponent} sequence.
+func rsaImportFingerprint(pubKeyBytes []byte) (string, error) {
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubKeyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	cryptoKey, ok := pubKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return "", fmt.Errorf("unsupported key type %s", pubKey.Type())
+	}
+
+	rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("AWS import fingerprints are only computable for RSA keys, got %s", pubKey.Type())
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(rsaKey)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling public key: %v", err)
+	}
+	sum := md5.Sum(der)
+
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = hex.EncodeToString([]byte{b})
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+// teardownKeyPair removes the cluster's key pair if EnsureKeyPair imported it.
+func (c *Cluster) teardownKeyPair() error {
+	ec2Svc := ec2.New(c.session)
+
+	resp, err := ec2Svc.DescribeKeyPairs(&ec2.DescribeKeyPairsInput{
+		KeyNames: []*string{aws.String(c.ClusterName)},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "InvalidKeyPair.NotFound") {
+			return nil
+		}
+		return fmt.Errorf("error describing key pair %s: %v", c.ClusterName, err)
+	}
+	if len(resp.KeyPairs) == 0 || !keyPairIsManaged(resp.KeyPairs[0]) {
+		return nil
+	}
+
+	if _, err := ec2Svc.DeleteKeyPair(&ec2.DeleteKeyPairInput{KeyName: aws.String(c.ClusterName)}); err != nil {
+		return fmt.Errorf("error deleting imported key pair %s: %v", c.ClusterName, err)
+	}
+	return nil
+}
+
+func keyPairIsManaged(kp *ec2.KeyPairInfo) bool {
+	for _, tag := range kp.Tags {
+		if aws.StringValue(tag.Key) == keyPairManagedTag {
+			return true
+		}
+	}
+	return false
+}
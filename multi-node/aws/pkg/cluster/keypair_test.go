@@ -0,0 +1,142 @@
+package cluster
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// writeTestPublicKey generates an RSA key pair and writes its OpenSSH
+// public key to a temp file, returning the path.
+func writeTestPublicKey(t *testing.T) string {
+	t.Helper()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating test key: %v", err)
+	}
+
+	pub, err := ssh.NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("error deriving ssh public key: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "id_rsa.pub")
+	if err := ioutil.WriteFile(path, ssh.MarshalAuthorizedKey(pub), 0644); err != nil {
+		t.Fatalf("error writing test public key: %v", err)
+	}
+	return path
+}
+
+type fakeEC2Client struct {
+	ec2iface.EC2API
+
+	describeKeyPairsOutput *ec2.DescribeKeyPairsOutput
+	describeKeyPairsErr    error
+	importKeyPairOutput    *ec2.ImportKeyPairOutput
+	createTagsCalled       bool
+}
+
+func (f *fakeEC2Client) DescribeKeyPairs(in *ec2.DescribeKeyPairsInput) (*ec2.DescribeKeyPairsOutput, error) {
+	if f.describeKeyPairsErr != nil {
+		return nil, f.describeKeyPairsErr
+	}
+	return f.describeKeyPairsOutput, nil
+}
+
+func (f *fakeEC2Client) ImportKeyPair(in *ec2.ImportKeyPairInput) (*ec2.ImportKeyPairOutput, error) {
+	return f.importKeyPairOutput, nil
+}
+
+func (f *fakeEC2Client) CreateTags(in *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	f.createTagsCalled = true
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func TestEnsureKeyPairFindsExistingByFingerprint(t *testing.T) {
+	path := writeTestPublicKey(t)
+
+	client := &fakeEC2Client{
+		describeKeyPairsOutput: &ec2.DescribeKeyPairsOutput{
+			KeyPairs: []*ec2.KeyPairInfo{{KeyName: aws.String("some-other-name")}},
+		},
+	}
+
+	name, err := ensureKeyPair(client, "my-cluster", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "some-other-name" {
+		t.Errorf("expected the existing key's name to be returned, got %s", name)
+	}
+	if client.createTagsCalled {
+		t.Error("did not expect CreateTags to be called when reusing an existing key")
+	}
+}
+
+func TestEnsureKeyPairImportsWhenMissing(t *testing.T) {
+	path := writeTestPublicKey(t)
+
+	client := &fakeEC2Client{
+		describeKeyPairsOutput: &ec2.DescribeKeyPairsOutput{},
+		importKeyPairOutput: &ec2.ImportKeyPairOutput{
+			KeyName:   aws.String("my-cluster"),
+			KeyPairId: aws.String("key-0123456789abcdef0"),
+		},
+	}
+
+	name, err := ensureKeyPair(client, "my-cluster", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "my-cluster" {
+		t.Errorf("expected the imported key's name to be returned, got %s", name)
+	}
+	if !client.createTagsCalled {
+		t.Error("expected the newly imported key pair to be tagged as managed")
+	}
+}
+
+func TestEnsureKeyPairPropagatesDescribeError(t *testing.T) {
+	path := writeTestPublicKey(t)
+
+	client := &fakeEC2Client{describeKeyPairsErr: fmt.Errorf("boom")}
+
+	if _, err := ensureKeyPair(client, "my-cluster", path); err == nil {
+		t.Error("expected the DescribeKeyPairs error to propagate")
+	}
+}
+
+func TestRSAImportFingerprintRejectsUnparseableKeys(t *testing.T) {
+	if _, err := rsaImportFingerprint([]byte("not a key")); err == nil {
+		t.Error("expected an error for unparseable key material")
+	}
+}
+
+// TestRSAImportFingerprintMatchesAWS pins a known public key against its
+// expected AWS import fingerprint (the MD5 of its DER-encoded X.509
+// SubjectPublicKeyInfo, independently verified with
+// `openssl rsa -pubin -pubout -outform DER | openssl md5`), so a regression
+// back to hashing the bare PKCS#1 form fails loudly instead of just making
+// ensureKeyPair re-import the same key on every run.
+func TestRSAImportFingerprintMatchesAWS(t *testing.T) {
+	const pubKey = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQDZ3R8WqS0NCWH1/cykhLpzUzDxM3HDjPnLOsyISDMMfHzO3/CgJxM1ohzCAg9z3IDCeHixa6GxGVbwAzwEArgPnlTnBcP0Qhsb47OtiHKzncOaPSAOx2fcwC6yyOu6wvt6nBWo9O6JvuoLQo0dQw+SQFvUn7wmTY1/+r6UcnRpmXDo71ho/TwbnL+xj9L3brM1Bzixc510jdVJDmjZZlj+yyEJe4k1g9YF7Wd5K0xGRgWWjkXZqEaBXqMwAAT22sa4JvjUO2o2WOzXMn1QBHs4zzS7pAQN9lofHuHQAykeS2dlauizZ8t4FKONz7volAO4242FkyBN9wEOE3jxYOsn"
+	const wantFingerprint = "5c:cd:32:f6:6a:0b:53:d7:e2:49:93:02:47:b7:a4:29"
+
+	got, err := rsaImportFingerprint([]byte(pubKey))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != wantFingerprint {
+		t.Errorf("expected fingerprint %s, got %s", wantFingerprint, got)
+	}
+}
@@ -0,0 +1,195 @@
+package cluster
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+)
+
+// StackPhase is a coarse classification of a CloudFormation stack's status.
+type StackPhase string
+
+const (
+	StackPhaseNotExist     StackPhase = "NOT_EXIST"
+	StackPhaseInProgress   StackPhase = "IN_PROGRESS"
+	StackPhaseDeleting     StackPhase = "DELETING"
+	StackPhaseRollbackDone StackPhase = "ROLLBACK_COMPLETE"
+	StackPhaseReady        StackPhase = "READY"
+	StackPhaseFailed       StackPhase = "FAILED"
+)
+
+// StackFailedError carries the resource event that caused a stack to reach
+// a terminal failure state.
+type StackFailedError struct {
+	Status            string
+	LogicalResourceID string
+	Reason            string
+}
+
+func (e *StackFailedError) Error() string {
+	if e.LogicalResourceID == "" {
+		return fmt.Sprintf("stack is in %s: %s", e.Status, e.Reason)
+	}
+	return fmt.Sprintf("stack is in %s: resource %s failed: %s", e.Status, e.LogicalResourceID, e.Reason)
+}
+
+func stackPhase(status string) StackPhase {
+	switch status {
+	case cloudformation.ResourceStatusCreateComplete, cloudformation.ResourceStatusUpdateComplete:
+		return StackPhaseReady
+	case cloudformation.StackStatusRollbackComplete:
+		return StackPhaseRollbackDone
+	case cloudformation.StackStatusDeleteInProgress:
+		return StackPhaseDeleting
+	case cloudformation.ResourceStatusCreateInProgress, cloudformation.ResourceStatusUpdateInProgress, cloudformation.StackStatusUpdateRollbackInProgress:
+		return StackPhaseInProgress
+	case cloudformation.ResourceStatusCreateFailed, cloudformation.ResourceStatusUpdateFailed, cloudformation.StackStatusDeleteFailed,
+		cloudformation.StackStatusUpdateRollbackComplete, cloudformation.StackStatusUpdateRollbackFailed, cloudformation.StackStatusRollbackFailed:
+		return StackPhaseFailed
+	default:
+		return StackPhaseFailed
+	}
+}
+
+// Status describes the current phase of the cluster's CloudFormation stack.
+func (c *Cluster) Status() (StackPhase, error) {
+	return statusOf(cloudformation.New(c.session), c.ClusterName)
+}
+
+func statusOf(cfSvc cloudformationiface.CloudFormationAPI, stackName string) (StackPhase, error) {
+	stack, err := describeStack(cfSvc, stackName)
+	if err != nil {
+		if stackNotExistErr(err, stackName) {
+			return StackPhaseNotExist, nil
+		}
+		return "", err
+	}
+	if stack == nil {
+		return StackPhaseNotExist, nil
+	}
+	return stackPhase(aws.StringValue(stack.StackStatus)), nil
+}
+
+func describeStack(cfSvc cloudformationiface.CloudFormationAPI, stackName string) (*cloudformation.Stack, error) {
+	resp, err := cfSvc.DescribeStacks(&cloudformation.DescribeStacksInput{StackName: aws.String(stackName)})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Stacks) == 0 {
+		return nil, nil
+	}
+	return resp.Stacks[0], nil
+}
+
+// Adopt attaches to an existing CloudFormation stack and streams its events
+// to out until it reaches a terminal state.
+func (c *Cluster) Adopt(stackName string, out io.Writer) error {
+	cfSvc := cloudformation.New(c.session)
+	stack, err := describeStack(cfSvc, stackName)
+	if err != nil {
+		return fmt.Errorf("error describing stack %s: %v", stackName, err)
+	}
+	if stack == nil {
+		return fmt.Errorf("stack %s not found", stackName)
+	}
+
+	if _, err := streamStackEvents(cfSvc, aws.StringValue(stack.StackId), out); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Create starts creation of the cluster's CloudFormation stack and streams
+// its events to out. If a stack with this name already exists, Create
+// does not blindly issue CreateStack: it deletes a ROLLBACK_COMPLETE stack
+// and recreates it, attaches to a stack still in progress, waits out a
+// deletion and then creates, and returns an error for a stack that is
+// already complete or has failed.
+func (c *Cluster) Create(stackBody string, out io.Writer) error {
+	return create(cloudformation.New(c.session), c.ClusterName, stackBody, c.resolveStackParameters, out)
+}
+
+func create(cfSvc cloudformationiface.CloudFormationAPI, clusterName, stackBody string, resolveParams func() ([]*cloudformation.Parameter, error), out io.Writer) error {
+	stack, err := describeStack(cfSvc, clusterName)
+	if err != nil && !stackNotExistErr(err, clusterName) {
+		return fmt.Errorf("error describing stack %s: %v", clusterName, err)
+	}
+
+	if stack != nil {
+		switch stackPhase(aws.StringValue(stack.StackStatus)) {
+		case StackPhaseRollbackDone:
+			if err := deleteAndAwait(cfSvc, clusterName, stack, out); err != nil {
+				return err
+			}
+		case StackPhaseInProgress:
+			_, err := streamStackEvents(cfSvc, aws.StringValue(stack.StackId), out)
+			return err
+		case StackPhaseDeleting:
+			if _, err := streamStackEvents(cfSvc, aws.StringValue(stack.StackId), out); err != nil {
+				return err
+			}
+		case StackPhaseReady:
+			return fmt.Errorf("stack %s already exists", clusterName)
+		default:
+			return failedStackError(cfSvc, stack)
+		}
+	}
+
+	params, err := resolveParams()
+	if err != nil {
+		return err
+	}
+
+	creq := &cloudformation.CreateStackInput{
+		StackName:    aws.String(clusterName),
+		OnFailure:    aws.String("DO_NOTHING"),
+		Capabilities: []*string{aws.String(cloudformation.CapabilityCapabilityIam)},
+		TemplateBody: &stackBody,
+		Parameters:   params,
+	}
+	resp, err := cfSvc.CreateStack(creq)
+	if err != nil {
+		return err
+	}
+
+	if _, err := streamStackEvents(cfSvc, aws.StringValue(resp.StackId), out); err != nil {
+		return fmt.Errorf("stack creation failed: %v", err)
+	}
+	return nil
+}
+
+func failedStackError(cfSvc cloudformationiface.CloudFormationAPI, stack *cloudformation.Stack) error {
+	status := aws.StringValue(stack.StackStatus)
+	reason := aws.StringValue(stack.StackStatusReason)
+
+	resp, err := cfSvc.DescribeStackEvents(&cloudformation.DescribeStackEventsInput{
+		StackName: stack.StackId,
+	})
+	if err != nil {
+		return &StackFailedError{Status: status, Reason: reason}
+	}
+
+	for _, e := range resp.StackEvents {
+		if isFailedResourceStatus(aws.StringValue(e.ResourceStatus)) {
+			return &StackFailedError{
+				Status:            status,
+				LogicalResourceID: aws.StringValue(e.LogicalResourceId),
+				Reason:            aws.StringValue(e.ResourceStatusReason),
+			}
+		}
+	}
+	return &StackFailedError{Status: status, Reason: reason}
+}
+
+func deleteAndAwait(cfSvc cloudformationiface.CloudFormationAPI, clusterName string, stack *cloudformation.Stack, out io.Writer) error {
+	if _, err := cfSvc.DeleteStack(&cloudformation.DeleteStackInput{StackName: stack.StackId}); err != nil {
+		return fmt.Errorf("error deleting dead stack %s: %v", clusterName, err)
+	}
+	if _, err := streamStackEvents(cfSvc, aws.StringValue(stack.StackId), out); err != nil {
+		return fmt.Errorf("error waiting for dead stack %s to delete: %v", clusterName, err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package cluster
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestStackPhase(t *testing.T) {
+	cases := []struct {
+		status string
+		want   StackPhase
+	}{
+		{cloudformation.ResourceStatusCreateComplete, StackPhaseReady},
+		{cloudformation.ResourceStatusUpdateComplete, StackPhaseReady},
+		{cloudformation.StackStatusRollbackComplete, StackPhaseRollbackDone},
+		{cloudformation.StackStatusDeleteInProgress, StackPhaseDeleting},
+		{cloudformation.ResourceStatusCreateInProgress, StackPhaseInProgress},
+		{cloudformation.ResourceStatusUpdateInProgress, StackPhaseInProgress},
+		{cloudformation.ResourceStatusCreateFailed, StackPhaseFailed},
+		{cloudformation.StackStatusDeleteFailed, StackPhaseFailed},
+	}
+
+	for _, tc := range cases {
+		if got := stackPhase(tc.status); got != tc.want {
+			t.Errorf("stackPhase(%s) = %s, want %s", tc.status, got, tc.want)
+		}
+	}
+}
+
+// describeStacksCFClient answers DescribeStacks either with a not-found
+// error or with a single stack in the given status.
+type describeStacksCFClient struct {
+	fakeCFClient
+	notFound  bool
+	stackName string
+	status    string
+	reason    string
+}
+
+func (f *describeStacksCFClient) DescribeStacks(in *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	if f.notFound {
+		return nil, awsValidationErr(f.stackName)
+	}
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{{
+			StackId:           aws.String(f.stackName),
+			StackStatus:       aws.String(f.status),
+			StackStatusReason: aws.String(f.reason),
+		}},
+	}, nil
+}
+
+func TestStatusOfNotExist(t *testing.T) {
+	client := &describeStacksCFClient{notFound: true, stackName: "my-cluster"}
+	phase, err := statusOf(client, "my-cluster")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if phase != StackPhaseNotExist {
+		t.Errorf("expected %s, got %s", StackPhaseNotExist, phase)
+	}
+}
+
+func TestStatusOfRollbackComplete(t *testing.T) {
+	client := &describeStacksCFClient{stackName: "my-cluster", status: cloudformation.StackStatusRollbackComplete}
+	phase, err := statusOf(client, "my-cluster")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if phase != StackPhaseRollbackDone {
+		t.Errorf("expected %s, got %s", StackPhaseRollbackDone, phase)
+	}
+}
+
+// createCFClient extends fakeCFClient with CreateStack/DeleteStack so tests
+// can drive create's state machine and assert which calls it made.
+type createCFClient struct {
+	fakeCFClient
+	createStackCalled bool
+	deleteStackCalled bool
+}
+
+func (f *createCFClient) CreateStack(in *cloudformation.CreateStackInput) (*cloudformation.CreateStackOutput, error) {
+	f.createStackCalled = true
+	return &cloudformation.CreateStackOutput{StackId: in.StackName}, nil
+}
+
+func (f *createCFClient) DeleteStack(in *cloudformation.DeleteStackInput) (*cloudformation.DeleteStackOutput, error) {
+	f.deleteStackCalled = true
+	return &cloudformation.DeleteStackOutput{}, nil
+}
+
+func noParams() ([]*cloudformation.Parameter, error) { return nil, nil }
+
+func emptyEventsOutputs(n int) []*cloudformation.DescribeStackEventsOutput {
+	outs := make([]*cloudformation.DescribeStackEventsOutput, n)
+	for i := range outs {
+		outs[i] = &cloudformation.DescribeStackEventsOutput{}
+	}
+	return outs
+}
+
+func TestCreateRollbackCompleteDeletesDeadStackAndRetries(t *testing.T) {
+	client := &createCFClient{fakeCFClient: fakeCFClient{
+		stacks: []*cloudformation.DescribeStacksOutput{
+			{Stacks: []*cloudformation.Stack{{StackId: aws.String("my-cluster"), StackStatus: aws.String(cloudformation.StackStatusRollbackComplete)}}},
+			{Stacks: []*cloudformation.Stack{{StackId: aws.String("my-cluster"), StackStatus: aws.String(cloudformation.StackStatusDeleteComplete)}}},
+			{Stacks: []*cloudformation.Stack{{StackId: aws.String("my-cluster"), StackStatus: aws.String(cloudformation.ResourceStatusCreateComplete)}}},
+		},
+		events: emptyEventsOutputs(2),
+	}}
+
+	if err := create(client, "my-cluster", "{}", noParams, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !client.deleteStackCalled {
+		t.Error("expected the dead stack to be deleted before retrying")
+	}
+	if !client.createStackCalled {
+		t.Error("expected CreateStack to be called after the dead stack was deleted")
+	}
+}
+
+func TestCreateInProgressAttachesInsteadOfCreating(t *testing.T) {
+	client := &createCFClient{fakeCFClient: fakeCFClient{
+		stacks: []*cloudformation.DescribeStacksOutput{
+			{Stacks: []*cloudformation.Stack{{StackId: aws.String("my-cluster"), StackStatus: aws.String(cloudformation.ResourceStatusCreateInProgress)}}},
+			{Stacks: []*cloudformation.Stack{{StackId: aws.String("my-cluster"), StackStatus: aws.String(cloudformation.ResourceStatusCreateComplete)}}},
+		},
+		events: emptyEventsOutputs(1),
+	}}
+
+	if err := create(client, "my-cluster", "{}", noParams, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.createStackCalled {
+		t.Error("expected CreateStack not to be called for a stack already in progress")
+	}
+	if client.deleteStackCalled {
+		t.Error("expected DeleteStack not to be called for a stack already in progress")
+	}
+}
+
+func TestCreateDeletingWaitsThenCreates(t *testing.T) {
+	client := &createCFClient{fakeCFClient: fakeCFClient{
+		stacks: []*cloudformation.DescribeStacksOutput{
+			{Stacks: []*cloudformation.Stack{{StackId: aws.String("my-cluster"), StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress)}}},
+			{Stacks: []*cloudformation.Stack{{StackId: aws.String("my-cluster"), StackStatus: aws.String(cloudformation.StackStatusDeleteComplete)}}},
+			{Stacks: []*cloudformation.Stack{{StackId: aws.String("my-cluster"), StackStatus: aws.String(cloudformation.ResourceStatusCreateComplete)}}},
+		},
+		events: emptyEventsOutputs(2),
+	}}
+
+	if err := create(client, "my-cluster", "{}", noParams, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.deleteStackCalled {
+		t.Error("did not expect DeleteStack to be called when the stack is already deleting on its own")
+	}
+	if !client.createStackCalled {
+		t.Error("expected CreateStack to be called once the stack finished deleting")
+	}
+}
+
+func TestCreateTerminalFailureReturnsFailedResourceEvent(t *testing.T) {
+	client := &createCFClient{fakeCFClient: fakeCFClient{
+		stacks: []*cloudformation.DescribeStacksOutput{
+			{Stacks: []*cloudformation.Stack{{
+				StackId:           aws.String("my-cluster"),
+				StackStatus:       aws.String(cloudformation.ResourceStatusCreateFailed),
+				StackStatusReason: aws.String("The following resource(s) failed to create"),
+			}}},
+		},
+		events: []*cloudformation.DescribeStackEventsOutput{{
+			StackEvents: []*cloudformation.StackEvent{
+				stackEvent("1", "Controller", cloudformation.ResourceStatusCreateFailed, "quota exceeded"),
+			},
+		}},
+	}}
+
+	err := create(client, "my-cluster", "{}", noParams, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected an error for a stack in a terminal failure state")
+	}
+	sfe, ok := err.(*StackFailedError)
+	if !ok {
+		t.Fatalf("expected a *StackFailedError, got %T: %v", err, err)
+	}
+	if sfe.LogicalResourceID != "Controller" || sfe.Reason != "quota exceeded" {
+		t.Errorf("expected the error to name the failed resource, got %+v", sfe)
+	}
+	if client.createStackCalled {
+		t.Error("did not expect CreateStack to be called for a stack in a terminal failure state")
+	}
+}
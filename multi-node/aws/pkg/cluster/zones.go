@@ -0,0 +1,147 @@
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+
+	"github.com/coreos/coreos-kubernetes/multi-node/aws/pkg/config"
+)
+
+const optInStatusOptedIn = "opted-in"
+
+// validateZone checks that subnet.AvailabilityZone matches its configured
+// ZoneType. For an edge zone it also checks that the zone is opted in and,
+// when they are set, that it has ParentZoneName as its parent and offers
+// InstanceType.
+func validateZone(ec2Svc ec2iface.EC2API, subnet config.Subnet) error {
+	resp, err := ec2Svc.DescribeAvailabilityZones(&ec2.DescribeAvailabilityZonesInput{
+		ZoneNames: []*string{aws.String(subnet.AvailabilityZone)},
+		Filters: []*ec2.Filter{
+			{Name: aws.String("zone-type"), Values: []*string{aws.String(string(subnet.ZoneType))}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error describing availability zone %s: %v", subnet.AvailabilityZone, err)
+	}
+	if len(resp.AvailabilityZones) == 0 {
+		return fmt.Errorf("%s is not a %s zone", subnet.AvailabilityZone, subnet.ZoneType)
+	}
+
+	zone := resp.AvailabilityZones[0]
+	if subnet.ZoneType != config.ZoneTypeAvailabilityZone && aws.StringValue(zone.OptInStatus) != optInStatusOptedIn {
+		return fmt.Errorf("%s is a %s zone and must be opted in before it can be used (current status: %s)",
+			subnet.AvailabilityZone, subnet.ZoneType, aws.StringValue(zone.OptInStatus))
+	}
+
+	if subnet.ZoneType != config.ZoneTypeAvailabilityZone && subnet.ParentZoneName != "" &&
+		aws.StringValue(zone.ParentZoneName) != subnet.ParentZoneName {
+		return fmt.Errorf("%s has parent zone %s, expected %s",
+			subnet.AvailabilityZone, aws.StringValue(zone.ParentZoneName), subnet.ParentZoneName)
+	}
+
+	if subnet.ZoneType != config.ZoneTypeAvailabilityZone && subnet.InstanceType != "" {
+		if err := validateInstanceTypeOffering(ec2Svc, subnet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateInstanceTypeOffering(ec2Svc ec2iface.EC2API, subnet config.Subnet) error {
+	resp, err := ec2Svc.DescribeInstanceTypeOfferings(&ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: aws.String(ec2.LocationTypeAvailabilityZone),
+		Filters: []*ec2.Filter{
+			{Name: aws.String("location"), Values: []*string{aws.String(subnet.AvailabilityZone)}},
+			{Name: aws.String("instance-type"), Values: []*string{aws.String(subnet.InstanceType)}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error describing instance type offerings for %s: %v", subnet.AvailabilityZone, err)
+	}
+	if len(resp.InstanceTypeOfferings) == 0 {
+		return fmt.Errorf("instance type %s is not offered in %s zone %s", subnet.InstanceType, subnet.ZoneType, subnet.AvailabilityZone)
+	}
+	return nil
+}
+
+func validateSubnetCIDR(subnetCIDR string, vpcNet *net.IPNet, existing []*ec2.Subnet) error {
+	subnetIP, subnetNet, err := net.ParseCIDR(subnetCIDR)
+	if err != nil {
+		return fmt.Errorf("error parsing subnet cidr %s : %v", subnetCIDR, err)
+	}
+
+	if !vpcNet.Contains(subnetIP) {
+		return fmt.Errorf("subnet cidr (%s) is not contained by vpc cidr (%s)", subnetNet, vpcNet)
+	}
+
+	for _, existingSubnet := range existing {
+		existingIP, existingNet, err := net.ParseCIDR(*existingSubnet.CidrBlock)
+		if err != nil {
+			return fmt.Errorf("error parsing existing subnet cidr %s : %v", *existingSubnet.CidrBlock, err)
+		}
+		if existingNet.Contains(subnetIP) || subnetNet.Contains(existingIP) {
+			return fmt.Errorf("subnet cidr (%s) conflicts with existing subnet %s, cidr=%s", subnetNet, *existingSubnet.SubnetId, existingNet)
+		}
+	}
+
+	return nil
+}
+
+// hasInternetGatewayRoute reports whether table routes through an Internet
+// Gateway; a Wavelength subnet routes through a carrier gateway instead and
+// must never share a route table with one that does.
+func hasInternetGatewayRoute(table *ec2.RouteTable) bool {
+	for _, route := range table.Routes {
+		if route.GatewayId != nil && strings.HasPrefix(*route.GatewayId, "igw-") {
+			return true
+		}
+	}
+	return false
+}
+
+func validateWavelengthRouteTables(ec2Svc ec2iface.EC2API, subnet config.Subnet, vpcID string) error {
+	if subnet.ZoneType != config.ZoneTypeWavelengthZone {
+		return nil
+	}
+
+	subnetsInZone, err := ec2Svc.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{aws.String(vpcID)}},
+			{Name: aws.String("availability-zone"), Values: []*string{aws.String(subnet.AvailabilityZone)}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error describing subnets in %s: %v", subnet.AvailabilityZone, err)
+	}
+	if len(subnetsInZone.Subnets) == 0 {
+		return nil
+	}
+
+	subnetIDs := make([]*string, len(subnetsInZone.Subnets))
+	for i, s := range subnetsInZone.Subnets {
+		subnetIDs[i] = s.SubnetId
+	}
+
+	tables, err := ec2Svc.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("association.subnet-id"), Values: subnetIDs},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error describing route tables for vpc %s: %v", vpcID, err)
+	}
+
+	for _, table := range tables.RouteTables {
+		if hasInternetGatewayRoute(table) {
+			return fmt.Errorf("wavelength zone subnet in %s cannot share a route table with an existing public availability-zone subnet", subnet.AvailabilityZone)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package cluster
+
+import (
+	"net"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+
+	"github.com/coreos/coreos-kubernetes/multi-node/aws/pkg/config"
+)
+
+type fakeInstanceOfferingsEC2Client struct {
+	ec2iface.EC2API
+
+	offerings []*ec2.InstanceTypeOffering
+}
+
+func (f *fakeInstanceOfferingsEC2Client) DescribeInstanceTypeOfferings(in *ec2.DescribeInstanceTypeOfferingsInput) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	return &ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: f.offerings}, nil
+}
+
+func TestValidateSubnetCIDR(t *testing.T) {
+	_, vpcNet, _ := net.ParseCIDR("10.0.0.0/16")
+	existing := []*ec2.Subnet{
+		{SubnetId: aws.String("subnet-existing"), CidrBlock: aws.String("10.0.1.0/24")},
+	}
+
+	if err := validateSubnetCIDR("10.0.2.0/24", vpcNet, existing); err != nil {
+		t.Errorf("expected a disjoint, contained subnet to validate, got: %v", err)
+	}
+
+	if err := validateSubnetCIDR("10.0.1.0/25", vpcNet, existing); err == nil {
+		t.Error("expected an overlapping subnet to be rejected")
+	}
+
+	if err := validateSubnetCIDR("10.1.0.0/24", vpcNet, existing); err == nil {
+		t.Error("expected a subnet outside the vpc cidr to be rejected")
+	}
+}
+
+func TestHasInternetGatewayRoute(t *testing.T) {
+	withIGW := &ec2.RouteTable{Routes: []*ec2.Route{
+		{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-0123456789abcdef0")},
+	}}
+	withoutIGW := &ec2.RouteTable{Routes: []*ec2.Route{
+		{DestinationCidrBlock: aws.String("0.0.0.0/0"), CarrierGatewayId: aws.String("cagw-0123456789abcdef0")},
+	}}
+
+	if !hasInternetGatewayRoute(withIGW) {
+		t.Error("expected a route table with an igw- route to be detected")
+	}
+	if hasInternetGatewayRoute(withoutIGW) {
+		t.Error("did not expect a carrier-gateway-only route table to be detected as an igw route table")
+	}
+}
+
+// fakeAvailabilityZoneEC2Client answers DescribeAvailabilityZones with a
+// single fixed zone, regardless of the filters passed in.
+type fakeAvailabilityZoneEC2Client struct {
+	ec2iface.EC2API
+
+	zone *ec2.AvailabilityZone
+}
+
+func (f *fakeAvailabilityZoneEC2Client) DescribeAvailabilityZones(in *ec2.DescribeAvailabilityZonesInput) (*ec2.DescribeAvailabilityZonesOutput, error) {
+	return &ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []*ec2.AvailabilityZone{f.zone}}, nil
+}
+
+func TestValidateZoneRejectsWrongParentZone(t *testing.T) {
+	client := &fakeAvailabilityZoneEC2Client{zone: &ec2.AvailabilityZone{
+		OptInStatus:    aws.String(optInStatusOptedIn),
+		ParentZoneName: aws.String("us-west-2"),
+	}}
+
+	subnet := config.Subnet{
+		AvailabilityZone: "us-west-2-lax-1a",
+		ZoneType:         config.ZoneTypeLocalZone,
+		ParentZoneName:   "us-east-1",
+	}
+	if err := validateZone(client, subnet); err == nil {
+		t.Error("expected a subnet with the wrong configured ParentZoneName to be rejected")
+	}
+
+	subnet.ParentZoneName = "us-west-2"
+	if err := validateZone(client, subnet); err != nil {
+		t.Errorf("expected a subnet with the correct ParentZoneName to validate, got: %v", err)
+	}
+}
+
+func TestValidateInstanceTypeOffering(t *testing.T) {
+	subnet := config.Subnet{AvailabilityZone: "us-west-2-lax-1a", ZoneType: config.ZoneTypeLocalZone, InstanceType: "t3.medium"}
+
+	offered := &fakeInstanceOfferingsEC2Client{offerings: []*ec2.InstanceTypeOffering{
+		{InstanceType: aws.String("t3.medium")},
+	}}
+	if err := validateInstanceTypeOffering(offered, subnet); err != nil {
+		t.Errorf("expected an offered instance type to validate, got: %v", err)
+	}
+
+	notOffered := &fakeInstanceOfferingsEC2Client{}
+	if err := validateInstanceTypeOffering(notOffered, subnet); err == nil {
+		t.Error("expected an instance type missing from the zone's offerings to be rejected")
+	}
+}
+
+func TestZoneTypeConstants(t *testing.T) {
+	subnet := config.Subnet{ZoneType: config.ZoneTypeAvailabilityZone}
+	if subnet.ZoneType == config.ZoneTypeWavelengthZone {
+		t.Error("sanity: availability-zone subnet should not equal wavelength-zone constant")
+	}
+}
+
+// fakeRouteTablesEC2Client answers DescribeSubnets with a fixed set of
+// subnet ids in the zone and DescribeRouteTables with a fixed set of route
+// tables, regardless of the filters passed in.
+type fakeRouteTablesEC2Client struct {
+	ec2iface.EC2API
+
+	subnets     []*ec2.Subnet
+	routeTables []*ec2.RouteTable
+}
+
+func (f *fakeRouteTablesEC2Client) DescribeSubnets(in *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	return &ec2.DescribeSubnetsOutput{Subnets: f.subnets}, nil
+}
+
+func (f *fakeRouteTablesEC2Client) DescribeRouteTables(in *ec2.DescribeRouteTablesInput) (*ec2.DescribeRouteTablesOutput, error) {
+	return &ec2.DescribeRouteTablesOutput{RouteTables: f.routeTables}, nil
+}
+
+func TestValidateWavelengthRouteTablesRejectsSharedIGWRouteTable(t *testing.T) {
+	subnet := config.Subnet{AvailabilityZone: "us-east-1-wl1-bos-wlz-1", ZoneType: config.ZoneTypeWavelengthZone}
+
+	conflicting := &fakeRouteTablesEC2Client{
+		subnets: []*ec2.Subnet{{SubnetId: aws.String("subnet-0123456789abcdef0")}},
+		routeTables: []*ec2.RouteTable{{Routes: []*ec2.Route{
+			{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-0123456789abcdef0")},
+		}}},
+	}
+	if err := validateWavelengthRouteTables(conflicting, subnet, "vpc-0123456789abcdef0"); err == nil {
+		t.Error("expected a wavelength subnet sharing a route table with an igw route to be rejected")
+	}
+
+	clean := &fakeRouteTablesEC2Client{
+		subnets: []*ec2.Subnet{{SubnetId: aws.String("subnet-0123456789abcdef0")}},
+		routeTables: []*ec2.RouteTable{{Routes: []*ec2.Route{
+			{DestinationCidrBlock: aws.String("0.0.0.0/0"), CarrierGatewayId: aws.String("cagw-0123456789abcdef0")},
+		}}},
+	}
+	if err := validateWavelengthRouteTables(clean, subnet, "vpc-0123456789abcdef0"); err != nil {
+		t.Errorf("expected a wavelength subnet routed only through a carrier gateway to validate, got: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import "github.com/coreos/coreos-kubernetes/multi-node/aws/pkg/iam"
+
+// ZoneType distinguishes the kind of AWS zone a subnet lives in. Local
+// Zones and Wavelength Zones are opt-in extensions of a region that sit
+// closer to end users or carrier networks and, unlike an ordinary
+// availability-zone subnet, can't reach the internet through a standard
+// Internet Gateway.
+type ZoneType string
+
+const (
+	ZoneTypeAvailabilityZone ZoneType = "availability-zone"
+	ZoneTypeLocalZone        ZoneType = "local-zone"
+	ZoneTypeWavelengthZone   ZoneType = "wavelength-zone"
+)
+
+// Subnet is a single controller/worker subnet to place within the cluster's
+// VPC.
+type Subnet struct {
+	AvailabilityZone string
+	ZoneType         ZoneType
+	InstanceCIDR     string
+	// InstanceType is checked against the zone's offerings when ZoneType is
+	// an edge zone (Local Zone or Wavelength Zone), since those only carry a
+	// subset of a region's instance types.
+	InstanceType string
+	// ParentZoneName is checked against the zone's parent region/zone when
+	// ZoneType is an edge zone, so a Local or Wavelength Zone subnet can't be
+	// configured under the wrong parent.
+	ParentZoneName string
+}
+
+// Cluster holds the user-configurable parameters of a kube-aws cluster.
+type Cluster struct {
+	ClusterName     string
+	ExternalDNSName string
+	KeyName         string
+	// KeyPairPublicKeyPath is the local SSH public key to reconcile KeyName
+	// against when KeyName is left blank; see Cluster.EnsureKeyPair.
+	KeyPairPublicKeyPath string
+	Region               string
+	VPCID                string
+	VPCCIDR              string
+	InstanceCIDR         string
+	Subnets              []Subnet
+	// IAMRoles are reconciled directly against IAM before the stack is
+	// created or updated; see Cluster.Create.
+	IAMRoles []iam.Role
+}
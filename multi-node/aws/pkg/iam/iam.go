@@ -0,0 +1,170 @@
+// Package iam reconciles the IAM roles a kube-aws cluster depends on
+// directly against the IAM API, independently of its CloudFormation stack.
+package iam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// InlinePolicy is a single IAM policy document attached directly to a Role.
+type InlinePolicy struct {
+	Name     string
+	Document string
+}
+
+// Role describes the desired state of an IAM role.
+type Role struct {
+	Name                     string
+	AssumeRolePolicyDocument string
+	InlinePolicies           []InlinePolicy
+}
+
+// Reconcile makes the IAM role in AWS match r, creating it if needed, and
+// returns its ARN.
+func (r Role) Reconcile(sess *session.Session) (string, error) {
+	return r.reconcile(iam.New(sess))
+}
+
+func (r Role) reconcile(svc iamiface.IAMAPI) (string, error) {
+	resp, err := svc.GetRole(&iam.GetRoleInput{RoleName: aws.String(r.Name)})
+	if err != nil {
+		if !isNoSuchEntity(err) {
+			return "", fmt.Errorf("error getting role %s: %v", r.Name, err)
+		}
+		return r.create(svc)
+	}
+
+	actualDoc, err := url.QueryUnescape(aws.StringValue(resp.Role.AssumeRolePolicyDocument))
+	if err != nil {
+		return "", fmt.Errorf("error unescaping assume role policy for %s: %v", r.Name, err)
+	}
+
+	same, err := policyJSONEqual(r.AssumeRolePolicyDocument, actualDoc)
+	if err != nil {
+		return "", fmt.Errorf("error comparing assume role policy for %s: %v", r.Name, err)
+	}
+	if !same {
+		if _, err := svc.UpdateAssumeRolePolicy(&iam.UpdateAssumeRolePolicyInput{
+			RoleName:       aws.String(r.Name),
+			PolicyDocument: aws.String(r.AssumeRolePolicyDocument),
+		}); err != nil {
+			return "", fmt.Errorf("error updating assume role policy for %s: %v", r.Name, err)
+		}
+	}
+
+	if err := r.reconcileInlinePolicies(svc); err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(resp.Role.Arn), nil
+}
+
+func (r Role) create(svc iamiface.IAMAPI) (string, error) {
+	resp, err := svc.CreateRole(&iam.CreateRoleInput{
+		RoleName:                 aws.String(r.Name),
+		AssumeRolePolicyDocument: aws.String(r.AssumeRolePolicyDocument),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error creating role %s: %v", r.Name, err)
+	}
+
+	if err := r.reconcileInlinePolicies(svc); err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(resp.Role.Arn), nil
+}
+
+// reconcileInlinePolicies makes the role's inline policies in AWS match
+// r.InlinePolicies exactly, removing anything no longer listed.
+func (r Role) reconcileInlinePolicies(svc iamiface.IAMAPI) error {
+	existing, err := svc.ListRolePolicies(&iam.ListRolePoliciesInput{RoleName: aws.String(r.Name)})
+	if err != nil {
+		return fmt.Errorf("error listing inline policies for role %s: %v", r.Name, err)
+	}
+
+	wanted := make(map[string]bool, len(r.InlinePolicies))
+	for _, p := range r.InlinePolicies {
+		wanted[p.Name] = true
+	}
+
+	for _, name := range existing.PolicyNames {
+		if wanted[aws.StringValue(name)] {
+			continue
+		}
+		if _, err := svc.DeleteRolePolicy(&iam.DeleteRolePolicyInput{
+			RoleName:   aws.String(r.Name),
+			PolicyName: name,
+		}); err != nil {
+			return fmt.Errorf("error deleting inline policy %s on role %s: %v", aws.StringValue(name), r.Name, err)
+		}
+	}
+
+	for _, p := range r.InlinePolicies {
+		if err := r.reconcileInlinePolicy(svc, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r Role) reconcileInlinePolicy(svc iamiface.IAMAPI, p InlinePolicy) error {
+	resp, err := svc.GetRolePolicy(&iam.GetRolePolicyInput{
+		RoleName:   aws.String(r.Name),
+		PolicyName: aws.String(p.Name),
+	})
+	switch {
+	case err == nil:
+		actualDoc, uerr := url.QueryUnescape(aws.StringValue(resp.PolicyDocument))
+		if uerr != nil {
+			return fmt.Errorf("error unescaping inline policy %s on role %s: %v", p.Name, r.Name, uerr)
+		}
+		same, eerr := policyJSONEqual(p.Document, actualDoc)
+		if eerr != nil {
+			return fmt.Errorf("error comparing inline policy %s on role %s: %v", p.Name, r.Name, eerr)
+		}
+		if same {
+			return nil
+		}
+	case !isNoSuchEntity(err):
+		return fmt.Errorf("error getting inline policy %s on role %s: %v", p.Name, r.Name, err)
+	}
+
+	if _, err := svc.PutRolePolicy(&iam.PutRolePolicyInput{
+		RoleName:       aws.String(r.Name),
+		PolicyName:     aws.String(p.Name),
+		PolicyDocument: aws.String(p.Document),
+	}); err != nil {
+		return fmt.Errorf("error putting inline policy %s on role %s: %v", p.Name, r.Name, err)
+	}
+
+	return nil
+}
+
+// policyJSONEqual reports whether two IAM policy documents are equivalent
+// once parsed, so AWS's reformatting doesn't register as drift.
+func policyJSONEqual(expected, actual string) (bool, error) {
+	var expectedVal, actualVal map[string]interface{}
+	if err := json.Unmarshal([]byte(expected), &expectedVal); err != nil {
+		return false, fmt.Errorf("error parsing expected policy json: %v", err)
+	}
+	if err := json.Unmarshal([]byte(actual), &actualVal); err != nil {
+		return false, fmt.Errorf("error parsing actual policy json: %v", err)
+	}
+	return reflect.DeepEqual(expectedVal, actualVal), nil
+}
+
+func isNoSuchEntity(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == iam.ErrCodeNoSuchEntityException
+}
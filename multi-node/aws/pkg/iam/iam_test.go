@@ -0,0 +1,181 @@
+package iam
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+type fakeIAMClient struct {
+	iamiface.IAMAPI
+
+	role           *iam.Role
+	getRoleErr     error
+	inlinePolicies map[string]string
+
+	updatedAssumeRolePolicy string
+	createRoleCalled        bool
+	putPolicyCalls          map[string]string
+	deletedPolicies         []string
+}
+
+func newFakeIAMClient() *fakeIAMClient {
+	return &fakeIAMClient{
+		inlinePolicies: make(map[string]string),
+		putPolicyCalls: make(map[string]string),
+	}
+}
+
+func noSuchEntityErr() error {
+	return awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+}
+
+func (f *fakeIAMClient) GetRole(in *iam.GetRoleInput) (*iam.GetRoleOutput, error) {
+	if f.getRoleErr != nil {
+		return nil, f.getRoleErr
+	}
+	return &iam.GetRoleOutput{Role: f.role}, nil
+}
+
+func (f *fakeIAMClient) CreateRole(in *iam.CreateRoleInput) (*iam.CreateRoleOutput, error) {
+	f.createRoleCalled = true
+	return &iam.CreateRoleOutput{Role: &iam.Role{
+		RoleName: in.RoleName,
+		Arn:      aws.String("arn:aws:iam::123456789012:role/" + aws.StringValue(in.RoleName)),
+	}}, nil
+}
+
+func (f *fakeIAMClient) UpdateAssumeRolePolicy(in *iam.UpdateAssumeRolePolicyInput) (*iam.UpdateAssumeRolePolicyOutput, error) {
+	f.updatedAssumeRolePolicy = aws.StringValue(in.PolicyDocument)
+	return &iam.UpdateAssumeRolePolicyOutput{}, nil
+}
+
+func (f *fakeIAMClient) ListRolePolicies(in *iam.ListRolePoliciesInput) (*iam.ListRolePoliciesOutput, error) {
+	var names []*string
+	for name := range f.inlinePolicies {
+		names = append(names, aws.String(name))
+	}
+	return &iam.ListRolePoliciesOutput{PolicyNames: names}, nil
+}
+
+func (f *fakeIAMClient) GetRolePolicy(in *iam.GetRolePolicyInput) (*iam.GetRolePolicyOutput, error) {
+	doc, ok := f.inlinePolicies[aws.StringValue(in.PolicyName)]
+	if !ok {
+		return nil, noSuchEntityErr()
+	}
+	return &iam.GetRolePolicyOutput{PolicyDocument: aws.String(doc)}, nil
+}
+
+func (f *fakeIAMClient) PutRolePolicy(in *iam.PutRolePolicyInput) (*iam.PutRolePolicyOutput, error) {
+	f.putPolicyCalls[aws.StringValue(in.PolicyName)] = aws.StringValue(in.PolicyDocument)
+	return &iam.PutRolePolicyOutput{}, nil
+}
+
+func (f *fakeIAMClient) DeleteRolePolicy(in *iam.DeleteRolePolicyInput) (*iam.DeleteRolePolicyOutput, error) {
+	f.deletedPolicies = append(f.deletedPolicies, aws.StringValue(in.PolicyName))
+	return &iam.DeleteRolePolicyOutput{}, nil
+}
+
+func TestReconcileCreatesMissingRole(t *testing.T) {
+	client := newFakeIAMClient()
+	client.getRoleErr = noSuchEntityErr()
+
+	r := Role{Name: "controller", AssumeRolePolicyDocument: `{"Version":"2012-10-17"}`}
+
+	arn, err := r.reconcile(client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !client.createRoleCalled {
+		t.Error("expected CreateRole to be called for a missing role")
+	}
+	if arn != "arn:aws:iam::123456789012:role/controller" {
+		t.Errorf("unexpected arn: %s", arn)
+	}
+}
+
+func TestReconcileSkipsUpdateWhenPolicyMatchesAfterReformatting(t *testing.T) {
+	client := newFakeIAMClient()
+	client.role = &iam.Role{
+		RoleName: aws.String("controller"),
+		Arn:      aws.String("arn:aws:iam::123456789012:role/controller"),
+		// Same document as desired, just reformatted the way AWS echoes it back.
+		AssumeRolePolicyDocument: aws.String(`{"Statement":[],"Version":"2012-10-17"}`),
+	}
+
+	r := Role{Name: "controller", AssumeRolePolicyDocument: `{"Version": "2012-10-17", "Statement": []}`}
+
+	if _, err := r.reconcile(client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.updatedAssumeRolePolicy != "" {
+		t.Error("did not expect UpdateAssumeRolePolicy to be called for an unchanged policy")
+	}
+}
+
+func TestReconcileUpdatesDriftedAssumeRolePolicy(t *testing.T) {
+	client := newFakeIAMClient()
+	client.role = &iam.Role{
+		RoleName:                 aws.String("controller"),
+		Arn:                      aws.String("arn:aws:iam::123456789012:role/controller"),
+		AssumeRolePolicyDocument: aws.String(`{"Version":"2008-10-17"}`),
+	}
+
+	r := Role{Name: "controller", AssumeRolePolicyDocument: `{"Version":"2012-10-17"}`}
+
+	if _, err := r.reconcile(client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.updatedAssumeRolePolicy != `{"Version":"2012-10-17"}` {
+		t.Errorf("expected the drifted policy to be updated, got %q", client.updatedAssumeRolePolicy)
+	}
+}
+
+func TestReconcileInlinePoliciesAddsAndRemoves(t *testing.T) {
+	client := newFakeIAMClient()
+	client.role = &iam.Role{
+		RoleName:                 aws.String("controller"),
+		Arn:                      aws.String("arn:aws:iam::123456789012:role/controller"),
+		AssumeRolePolicyDocument: aws.String(`{"Version":"2012-10-17"}`),
+	}
+	client.inlinePolicies["stale"] = `{"Version":"2012-10-17"}`
+
+	r := Role{
+		Name:                     "controller",
+		AssumeRolePolicyDocument: `{"Version":"2012-10-17"}`,
+		InlinePolicies: []InlinePolicy{
+			{Name: "ecr-access", Document: `{"Version":"2012-10-17","Statement":[]}`},
+		},
+	}
+
+	if _, err := r.reconcile(client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.deletedPolicies) != 1 || client.deletedPolicies[0] != "stale" {
+		t.Errorf("expected the stale policy to be deleted, got %v", client.deletedPolicies)
+	}
+	if _, ok := client.putPolicyCalls["ecr-access"]; !ok {
+		t.Error("expected the new inline policy to be put")
+	}
+}
+
+func TestPolicyJSONEqual(t *testing.T) {
+	equal, err := policyJSONEqual(`{"a":1,"b":2}`, `{"b": 2, "a": 1}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !equal {
+		t.Error("expected reordered, re-whitespaced JSON to compare equal")
+	}
+
+	equal, err = policyJSONEqual(`{"a":1}`, `{"a":2}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if equal {
+		t.Error("expected differing values to compare unequal")
+	}
+}